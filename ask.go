@@ -0,0 +1,60 @@
+package easytcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DarthPestilane/easytcp/message"
+	"github.com/google/uuid"
+)
+
+// Ask sends req to the peer and blocks until a reply carrying the same
+// message.Entry.CorrelationID arrives, ctx is cancelled, or the session
+// closes. It lets server-initiated queries to clients (common in game
+// servers and signaling protocols) work without reinventing a correlation
+// map on top of RecvReq.
+//
+// Router.consumeRequest routes any incoming message whose CorrelationID
+// matches a pending Ask call straight to that call instead of the handler
+// chain, so req.ID's registered handler, if any, never sees the reply.
+func (s *Session) Ask(ctx context.Context, req *message.Entry) (*message.Entry, error) {
+	corrID := uuid.NewString()
+	req.CorrelationID = corrID
+
+	replyCh := make(chan *message.Entry, 1)
+	s.correlations.Store(corrID, replyCh)
+	defer s.correlations.Delete(corrID)
+
+	if err := s.SendResp(req); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reply := <-replyCh:
+		return reply, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.closed:
+		return nil, fmt.Errorf("session's closed")
+	}
+}
+
+// resolveCorrelation delivers entry to the channel registered by a
+// pending Ask call, if entry.CorrelationID matches one. It reports
+// whether such a call was found.
+func (s *Session) resolveCorrelation(entry *message.Entry) bool {
+	if entry.CorrelationID == "" {
+		return false
+	}
+	v, ok := s.correlations.Load(entry.CorrelationID)
+	if !ok {
+		return false
+	}
+	ch := v.(chan *message.Entry)
+	select {
+	case ch <- entry:
+	default:
+		Log.Tracef("dropped correlated reply %q: Ask caller already gone", entry.CorrelationID)
+	}
+	return true
+}
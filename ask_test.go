@@ -0,0 +1,59 @@
+package easytcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+func TestAskResolvesOnMatchingCorrelationID(t *testing.T) {
+	s := &Session{closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+
+	go func() {
+		req := <-s.respQueue
+		if !s.resolveCorrelation(&message.Entry{ID: "reply", CorrelationID: req.CorrelationID}) {
+			t.Error("resolveCorrelation didn't find the pending Ask call")
+		}
+	}()
+
+	reply, err := s.Ask(context.Background(), &message.Entry{ID: "req"})
+	if err != nil {
+		t.Fatalf("Ask returned error: %v", err)
+	}
+	if reply.ID != "reply" {
+		t.Fatalf("expected reply ID %q, got %v", "reply", reply.ID)
+	}
+}
+
+func TestAskReturnsErrOnContextCancel(t *testing.T) {
+	s := &Session{closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.Ask(ctx, &message.Entry{ID: "req"}); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestAskReturnsErrWhenSessionCloses(t *testing.T) {
+	s := &Session{closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	go func() {
+		<-s.respQueue
+		close(s.closed)
+	}()
+
+	if _, err := s.Ask(context.Background(), &message.Entry{ID: "req"}); err == nil {
+		t.Fatal("expected an error once the session closed while Ask was waiting")
+	}
+}
+
+func TestResolveCorrelationReportsNoMatch(t *testing.T) {
+	s := &Session{closed: make(chan struct{})}
+	if s.resolveCorrelation(&message.Entry{ID: "reply"}) {
+		t.Fatal("resolveCorrelation should report false for an entry with no CorrelationID")
+	}
+	if s.resolveCorrelation(&message.Entry{ID: "reply", CorrelationID: "unknown"}) {
+		t.Fatal("resolveCorrelation should report false when no Ask call is pending for that ID")
+	}
+}
@@ -0,0 +1,117 @@
+package easytcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RouteIDJoiner composes a RouteGroup's chain of prefixes and a route's
+// own ID into the single, comparable ID value used as the routing
+// table's key. Override it with Router.SetRouteIDJoiner for message ID
+// schemes the default doesn't fit.
+type RouteIDJoiner func(parts []interface{}) interface{}
+
+// defaultRouteIDJoiner joins string IDs into a dotted path ("auth.room").
+// It only really works for string IDs: a single part, i.e. no group
+// nesting, is returned unchanged regardless of type, but anything with
+// more than one part whose first part isn't a string is stringified and
+// slash-joined ("1/42") even when every part is, say, an int. That string is good enough to keep
+// printHandlers' route table legible and collision-free, but it won't
+// equal whatever int a handler actually receives off the wire, so
+// grouped routes with non-string IDs are unreachable through the default
+// joiner. Protocols with non-string message IDs that want grouping must
+// call SetRouteIDJoiner with a joiner that returns a value in the same
+// type and encoding their wire format actually sends.
+func defaultRouteIDJoiner(parts []interface{}) interface{} {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	sep := "/"
+	if _, ok := parts[0].(string); ok {
+		sep = "."
+	}
+	strs := make([]string, len(parts))
+	for i, p := range parts {
+		strs[i] = fmt.Sprintf("%v", p)
+	}
+	return strings.Join(strs, sep)
+}
+
+// SetRouteIDJoiner overrides how RouteGroup composes a chain of prefixes
+// and a route ID into the ID value stored in the routing table. Passing
+// nil restores the default joiner.
+func (r *Router) SetRouteIDJoiner(j RouteIDJoiner) {
+	r.idJoiner = j
+}
+
+func (r *Router) joinID(parts []interface{}) interface{} {
+	if r.idJoiner != nil {
+		return r.idJoiner(parts)
+	}
+	return defaultRouteIDJoiner(parts)
+}
+
+// RouteGroup is a scoped slice of the routing table: every ID registered
+// through it is composed with the group's own prefix (and any ancestor
+// groups' prefixes), and its middlewares are stacked between the
+// router's global middlewares and whatever's passed to Register. Groups
+// nest via RouteGroup.Group, so callers can express slices like
+// auth -> chat -> room of the routing table.
+type RouteGroup struct {
+	router   *Router
+	prefixes []interface{}
+	mws      []MiddlewareFunc
+}
+
+// Group returns a RouteGroup scoped under prefix. mws are stacked after
+// the router's global middlewares and before any middleware passed to
+// Register.
+func (r *Router) Group(prefix interface{}, mws ...MiddlewareFunc) *RouteGroup {
+	return &RouteGroup{
+		router:   r,
+		prefixes: []interface{}{prefix},
+		mws:      append([]MiddlewareFunc{}, mws...),
+	}
+}
+
+// Group returns a nested RouteGroup under g, composing prefix onto g's
+// own prefix chain and mws onto g's own middlewares.
+func (g *RouteGroup) Group(prefix interface{}, mws ...MiddlewareFunc) *RouteGroup {
+	prefixes := make([]interface{}, len(g.prefixes)+1)
+	copy(prefixes, g.prefixes)
+	prefixes[len(g.prefixes)] = prefix
+	return &RouteGroup{
+		router:   g.router,
+		prefixes: prefixes,
+		mws:      append(append([]MiddlewareFunc{}, g.mws...), mws...),
+	}
+}
+
+// Register stores handler and middlewares for id under the group's
+// composed prefix path, equivalent to Router.register but scoped to this
+// group's slice of the routing table.
+func (g *RouteGroup) Register(id interface{}, h HandlerFunc, m ...MiddlewareFunc) {
+	fullID := g.router.joinID(append(append([]interface{}{}, g.prefixes...), id))
+	g.router.register(fullID, h, append(append([]MiddlewareFunc{}, g.mws...), m...)...)
+}
+
+// renderRouteID formats id for printHandlers. It keeps the full
+// composed path a dotted or slash-joined RouteGroup ID already carries —
+// just the leaf would be ambiguous once rows are printed next to
+// sibling and cousin routes — and indents it by nesting depth so the
+// table still reads as a hierarchy.
+func renderRouteID(id interface{}) string {
+	s, ok := id.(string)
+	if !ok {
+		return fmt.Sprintf("%v", id)
+	}
+	sep := "."
+	if !strings.Contains(s, sep) {
+		sep = "/"
+	}
+	depth := strings.Count(s, sep)
+	if depth == 0 {
+		return s
+	}
+	return strings.Repeat("  ", depth) + s
+}
@@ -0,0 +1,50 @@
+package easytcp
+
+import (
+	"testing"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+func TestDefaultRouteIDJoinerSinglePart(t *testing.T) {
+	if id := defaultRouteIDJoiner([]interface{}{"room"}); id != "room" {
+		t.Fatalf("expected unchanged single part, got %v", id)
+	}
+	if id := defaultRouteIDJoiner([]interface{}{42}); id != 42 {
+		t.Fatalf("expected unchanged single part, got %v", id)
+	}
+}
+
+func TestDefaultRouteIDJoinerJoinsStringIDsWithDot(t *testing.T) {
+	id := defaultRouteIDJoiner([]interface{}{"auth", "room", "join"})
+	if id != "auth.room.join" {
+		t.Fatalf("expected dotted path, got %v", id)
+	}
+}
+
+// TestDefaultRouteIDJoinerNonStringIDsDontRoundTrip documents the default
+// joiner's known limitation: it never panics or collides on non-string
+// IDs, but the composed value is a string, not something equal to the
+// int a handler would actually receive off the wire for a grouped route.
+func TestDefaultRouteIDJoinerNonStringIDsDontRoundTrip(t *testing.T) {
+	id := defaultRouteIDJoiner([]interface{}{1, 42})
+	composed, ok := id.(string)
+	if !ok {
+		t.Fatalf("expected a string, got %T", id)
+	}
+	if composed != "1/42" {
+		t.Fatalf("expected slash-joined path, got %q", composed)
+	}
+}
+
+func TestRouteGroupRegisterComposesNestedPrefixes(t *testing.T) {
+	r := newRouter()
+
+	root := r.Group("auth")
+	nested := root.Group("room")
+	nested.Register("join", func(ctx *Context) (*message.Entry, error) { return nil, nil })
+
+	if _, ok := r.handlerMapper.Load("auth.room.join"); !ok {
+		t.Fatal("expected handler registered under composed ID \"auth.room.join\"")
+	}
+}
@@ -0,0 +1,103 @@
+package easytcp
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+// HeartbeatOption configures the Session's heartbeat subsystem.
+// A nil HeartbeatOption on SessionOption disables heartbeats entirely,
+// leaving readTimeout as the only liveness check.
+type HeartbeatOption struct {
+	// Interval is how often a ping message.Entry is sent to the peer.
+	Interval time.Duration
+
+	// Timeout is the max duration allowed to pass without receiving any
+	// bytes from the peer before the session is considered dead and closed.
+	Timeout time.Duration
+
+	// PingMessageID is the message.Entry.ID used for outgoing pings and
+	// recognized on incoming messages to trigger an automatic pong reply.
+	PingMessageID interface{}
+
+	// PongMessageID is the message.Entry.ID used for outgoing pongs and
+	// recognized on incoming messages to compute the round-trip time.
+	PongMessageID interface{}
+}
+
+// OnPing registers a callback invoked right after a ping is sent to the peer.
+func (s *Session) OnPing(fn func(*Session)) {
+	s.onPing = fn
+}
+
+// OnPong registers a callback invoked when a pong is received from the peer,
+// with rtt being the measured round-trip time since the last ping was sent.
+func (s *Session) OnPong(fn func(*Session, time.Duration)) {
+	s.onPong = fn
+}
+
+// Heartbeat sends a ping message.Entry to the peer every Interval, and
+// closes the session if no bytes are received within Timeout. It's a no-op
+// if the session was created without a HeartbeatOption.
+// Heartbeat should be run in its own goroutine, alongside ReadLoop and
+// WriteLoop. The loop exits once the session is closed.
+func (s *Session) Heartbeat() {
+	if s.heartbeat == nil {
+		return
+	}
+	if s.heartbeat.Interval <= 0 {
+		Log.Tracef("heartbeat interval is non-positive, heartbeat disabled")
+		return
+	}
+	ticker := time.NewTicker(s.heartbeat.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if s.heartbeat.Timeout > 0 {
+				last := time.Unix(0, atomic.LoadInt64(&s.lastRecvNano))
+				if time.Since(last) > s.heartbeat.Timeout {
+					Log.Tracef("session heartbeat timeout, closing session")
+					s.Close()
+					return
+				}
+			}
+			atomic.StoreInt64(&s.lastPingNano, time.Now().UnixNano())
+			if err := s.SendResp(&message.Entry{ID: s.heartbeat.PingMessageID}); err != nil {
+				Log.Tracef("send ping err: %s", err)
+				return
+			}
+			if s.onPing != nil {
+				s.onPing(s)
+			}
+		}
+	}
+}
+
+// handleHeartbeatMessage intercepts incoming ping and pong messages before
+// they reach reqQueue, so heartbeat traffic never goes through the router's
+// handler chain. It reports whether entry was a heartbeat message.
+func (s *Session) handleHeartbeatMessage(entry *message.Entry) bool {
+	switch entry.ID {
+	case s.heartbeat.PingMessageID:
+		if err := s.SendResp(&message.Entry{ID: s.heartbeat.PongMessageID}); err != nil {
+			Log.Tracef("send pong err: %s", err)
+		}
+		return true
+	case s.heartbeat.PongMessageID:
+		var rtt time.Duration
+		if sentAt := atomic.LoadInt64(&s.lastPingNano); sentAt > 0 {
+			rtt = time.Since(time.Unix(0, sentAt))
+		}
+		if s.onPong != nil {
+			s.onPong(s, rtt)
+		}
+		return true
+	default:
+		return false
+	}
+}
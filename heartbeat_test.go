@@ -0,0 +1,106 @@
+package easytcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+// TestHeartbeatGuardsNonPositiveInterval checks that a misconfigured
+// HeartbeatOption (Interval left at its zero value, e.g. a caller only
+// set Timeout) can't panic time.NewTicker — it just disables the loop.
+func TestHeartbeatGuardsNonPositiveInterval(t *testing.T) {
+	s := &Session{
+		closed:    make(chan struct{}),
+		reqQueue:  make(chan *message.Entry, 1),
+		respQueue: make(chan *message.Entry, 1),
+		heartbeat: &HeartbeatOption{Timeout: time.Second},
+	}
+	done := make(chan struct{})
+	go func() {
+		s.Heartbeat()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Heartbeat with a non-positive Interval never returned")
+	}
+}
+
+// TestHeartbeatSendsPingOnInterval checks that Heartbeat sends a ping
+// message.Entry to respQueue every Interval.
+func TestHeartbeatSendsPingOnInterval(t *testing.T) {
+	s := &Session{
+		closed:       make(chan struct{}),
+		reqQueue:     make(chan *message.Entry, 1),
+		respQueue:    make(chan *message.Entry, 1),
+		lastRecvNano: time.Now().UnixNano(),
+		heartbeat:    &HeartbeatOption{Interval: 10 * time.Millisecond, PingMessageID: "ping"},
+	}
+	go s.Heartbeat()
+	defer s.Close()
+
+	select {
+	case entry := <-s.respQueue:
+		if entry.ID != "ping" {
+			t.Fatalf("expected ping message, got %v", entry.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no ping sent within interval")
+	}
+}
+
+// TestHeartbeatClosesSessionOnTimeout checks that Heartbeat closes the
+// session once Timeout has passed without any bytes received.
+func TestHeartbeatClosesSessionOnTimeout(t *testing.T) {
+	s := &Session{
+		closed:       make(chan struct{}),
+		reqQueue:     make(chan *message.Entry, 1),
+		respQueue:    make(chan *message.Entry, 1),
+		lastRecvNano: time.Now().Add(-time.Hour).UnixNano(),
+		heartbeat:    &HeartbeatOption{Interval: 10 * time.Millisecond, Timeout: 20 * time.Millisecond, PingMessageID: "ping"},
+	}
+	go s.Heartbeat()
+
+	select {
+	case <-s.closed:
+	case <-time.After(time.Second):
+		t.Fatal("session was never closed after heartbeat timeout")
+	}
+}
+
+// TestHandleHeartbeatMessageRespondsToPing checks that an incoming ping
+// triggers an automatic pong reply.
+func TestHandleHeartbeatMessageRespondsToPing(t *testing.T) {
+	s := &Session{
+		closed:    make(chan struct{}),
+		respQueue: make(chan *message.Entry, 1),
+		heartbeat: &HeartbeatOption{PingMessageID: "ping", PongMessageID: "pong"},
+	}
+	if !s.handleHeartbeatMessage(&message.Entry{ID: "ping"}) {
+		t.Fatal("expected handleHeartbeatMessage to report a ping was handled")
+	}
+	select {
+	case entry := <-s.respQueue:
+		if entry.ID != "pong" {
+			t.Fatalf("expected pong reply, got %v", entry.ID)
+		}
+	default:
+		t.Fatal("expected a pong reply to be queued")
+	}
+}
+
+// TestHandleHeartbeatMessageIgnoresOthers checks that a non-heartbeat
+// message is reported as unhandled, so it falls through to reqQueue.
+func TestHandleHeartbeatMessageIgnoresOthers(t *testing.T) {
+	s := &Session{
+		closed:    make(chan struct{}),
+		respQueue: make(chan *message.Entry, 1),
+		heartbeat: &HeartbeatOption{PingMessageID: "ping", PongMessageID: "pong"},
+	}
+	if s.handleHeartbeatMessage(&message.Entry{ID: "chat"}) {
+		t.Fatal("expected handleHeartbeatMessage to report false for a non-heartbeat message")
+	}
+}
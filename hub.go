@@ -0,0 +1,135 @@
+package easytcp
+
+import (
+	"sync"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+// Broker fans a published message.Entry out across instances, so a
+// SessionHub's topics can span multiple easytcp server processes. The
+// default, in-process SessionHub doesn't need one; pass a Broker to
+// NewSessionHub to bridge topics through NATS, Redis, or similar.
+type Broker interface {
+	// PublishRemote sends entry to topic on every other node sharing this Broker.
+	PublishRemote(topic string, entry *message.Entry) error
+
+	// SubscribeRemote registers fn to be called whenever another node
+	// publishes to topic. It's called once per topic, the first time a
+	// session joins it locally.
+	SubscribeRemote(topic string, fn func(entry *message.Entry)) error
+}
+
+// SessionHub groups sessions into named topics and fans published
+// messages out to every session in a topic via its SendResp.
+type SessionHub struct {
+	mu     sync.RWMutex
+	topics map[string]map[string]*Session // topic -> session ID -> Session
+	broker Broker
+}
+
+// NewSessionHub creates a SessionHub. broker may be nil, in which case
+// Publish only fans out to sessions local to this process.
+func NewSessionHub(broker Broker) *SessionHub {
+	return &SessionHub{
+		topics: make(map[string]map[string]*Session),
+		broker: broker,
+	}
+}
+
+// Join adds session to topic.
+func (h *SessionHub) Join(session *Session, topic string) {
+	h.mu.Lock()
+	sessions, exists := h.topics[topic]
+	isNewTopic := !exists
+	if isNewTopic {
+		sessions = make(map[string]*Session)
+		h.topics[topic] = sessions
+	}
+	sessions[session.ID()] = session
+	h.mu.Unlock()
+
+	// SubscribeRemote is called without h.mu held: a broker that delivers
+	// a retained/last-value message synchronously on subscribe calls back
+	// into publishLocal, which also needs h.mu, and sync.RWMutex isn't
+	// reentrant — holding the lock here would deadlock on that kind of
+	// broker.
+	if isNewTopic && h.broker != nil {
+		if err := h.broker.SubscribeRemote(topic, func(entry *message.Entry) { h.publishLocal(topic, entry) }); err != nil {
+			Log.Errorf("hub subscribe remote topic %q err: %s", topic, err)
+		}
+	}
+}
+
+// Leave removes session from topic.
+func (h *SessionHub) Leave(session *Session, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaveLocked(session, topic)
+}
+
+// LeaveAll removes session from every topic it has joined. Session.Close
+// calls this so a closed session never lingers in a topic.
+func (h *SessionHub) LeaveAll(session *Session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for topic := range h.topics {
+		h.leaveLocked(session, topic)
+	}
+}
+
+func (h *SessionHub) leaveLocked(session *Session, topic string) {
+	sessions, ok := h.topics[topic]
+	if !ok {
+		return
+	}
+	delete(sessions, session.ID())
+	if len(sessions) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+// Publish fans entry out to every session joined to topic, both locally
+// and, if a Broker is configured, on every other node sharing it.
+func (h *SessionHub) Publish(topic string, entry *message.Entry) {
+	h.publishLocal(topic, entry)
+	if h.broker != nil {
+		if err := h.broker.PublishRemote(topic, entry); err != nil {
+			Log.Errorf("hub publish remote topic %q err: %s", topic, err)
+		}
+	}
+}
+
+// publishLocal snapshots topic's sessions under h.mu, then sends outside
+// the lock: SendResp blocks if a session's respQueue is full, and holding
+// h.mu across that would stall Join/Leave for every topic in the hub
+// behind one slow session, not just this one.
+func (h *SessionHub) publishLocal(topic string, entry *message.Entry) {
+	h.mu.RLock()
+	sessions := h.topics[topic]
+	snapshot := make([]*Session, 0, len(sessions))
+	for _, session := range sessions {
+		snapshot = append(snapshot, session)
+	}
+	h.mu.RUnlock()
+
+	for _, session := range snapshot {
+		if err := session.SendResp(entry); err != nil {
+			Log.Tracef("hub publish to session %s err: %s", session.ID(), err)
+		}
+	}
+}
+
+// JoinTopic joins the session owning ctx to topic on hub. It's a
+// convenience for handlers that subscribe the current session inline,
+// equivalent to hub.Join(ctx.Session(), topic).
+func (c *Context) JoinTopic(hub *SessionHub, topic string) {
+	hub.Join(c.session, topic)
+}
+
+// PublishTopic publishes entry to topic on hub. It's a convenience for
+// handlers that broadcast from within a request, equivalent to
+// hub.Publish(topic, entry).
+func (c *Context) PublishTopic(hub *SessionHub, topic string, entry *message.Entry) {
+	hub.Publish(topic, entry)
+}
@@ -0,0 +1,104 @@
+package easytcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+type stubBroker struct {
+	subscribeRemote func(topic string, fn func(entry *message.Entry)) error
+}
+
+func (b *stubBroker) PublishRemote(topic string, entry *message.Entry) error { return nil }
+
+func (b *stubBroker) SubscribeRemote(topic string, fn func(entry *message.Entry)) error {
+	return b.subscribeRemote(topic, fn)
+}
+
+// TestJoinDoesNotDeadlockOnSynchronousBroker checks that Join doesn't
+// hold h.mu while calling into the broker: a broker that delivers a
+// retained/last-value message synchronously on subscribe calls back into
+// publishLocal, which also needs h.mu, and sync.RWMutex isn't reentrant.
+func TestJoinDoesNotDeadlockOnSynchronousBroker(t *testing.T) {
+	broker := &stubBroker{
+		subscribeRemote: func(topic string, fn func(entry *message.Entry)) error {
+			fn(&message.Entry{ID: "retained"})
+			return nil
+		},
+	}
+	h := NewSessionHub(broker)
+	s := &Session{id: "s1", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		h.Join(s, "room")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Join deadlocked calling into a synchronous broker")
+	}
+}
+
+// TestJoinOnlySubscribesOnce checks that SubscribeRemote is called once
+// per topic, the first time a session joins it, not on every Join.
+func TestJoinOnlySubscribesOnce(t *testing.T) {
+	var calls int
+	broker := &stubBroker{
+		subscribeRemote: func(topic string, fn func(entry *message.Entry)) error {
+			calls++
+			return nil
+		},
+	}
+	h := NewSessionHub(broker)
+	s1 := &Session{id: "s1", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	s2 := &Session{id: "s2", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+
+	h.Join(s1, "room")
+	h.Join(s2, "room")
+
+	if calls != 1 {
+		t.Fatalf("expected SubscribeRemote to be called once, got %d", calls)
+	}
+}
+
+// TestPublishLocalDoesNotBlockHubOnSlowSession checks that a Publish
+// stuck sending to one session's full respQueue can't stall Join/Leave
+// for the rest of the hub.
+func TestPublishLocalDoesNotBlockHubOnSlowSession(t *testing.T) {
+	h := NewSessionHub(nil)
+	slow := &Session{id: "slow", closed: make(chan struct{}), respQueue: make(chan *message.Entry)} // unbuffered, nobody reads
+	h.Join(slow, "room")
+
+	go h.Publish("room", &message.Entry{ID: "hello"}) // blocks forever sending to slow
+
+	other := &Session{id: "other", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	joined := make(chan struct{})
+	go func() {
+		h.Join(other, "room2")
+		close(joined)
+	}()
+
+	select {
+	case <-joined:
+	case <-time.After(time.Second):
+		t.Fatal("Join on an unrelated topic blocked behind Publish stuck on a slow session")
+	}
+}
+
+// TestLeaveRemovesEmptyTopic checks that a topic is cleaned up once its
+// last session leaves.
+func TestLeaveRemovesEmptyTopic(t *testing.T) {
+	h := NewSessionHub(nil)
+	s := &Session{id: "s1", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	h.Join(s, "room")
+	h.Leave(s, "room")
+
+	if _, ok := h.topics["room"]; ok {
+		t.Fatal("expected topic to be removed once its last session left")
+	}
+}
@@ -0,0 +1,19 @@
+package message
+
+// Entry is a application-level message, unpacked from and packed back
+// into the wire format by a Packer.
+type Entry struct {
+	// ID identifies the message, and is what Router uses to look up a handler.
+	ID interface{}
+
+	// Data is the message's raw payload.
+	Data []byte
+
+	// CorrelationID, when non-empty, marks Entry as part of a
+	// request/response exchange started by Session.Ask: Router routes
+	// any incoming Entry whose CorrelationID matches a pending Ask call
+	// straight back to it, instead of through the handler chain. Packer
+	// implementations are expected to carry this field over the wire
+	// alongside ID and Data.
+	CorrelationID string
+}
@@ -7,20 +7,42 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 )
 
-func newRouter(queueSize ...int) *Router {
-	size := 0
-	if len(queueSize) != 0 {
-		if qs := queueSize[0]; qs > 0 {
-			size = qs
-		}
+// RouterOption is the extra options for Router.
+type RouterOption struct {
+	// ReqCtxQueueSize is the buffer size of the router's incoming
+	// request-context queue.
+	ReqCtxQueueSize int
+
+	// WorkerPoolSize is the number of long-running worker goroutines that
+	// dispatch requests to handlers. A value <= 0 falls back to spawning
+	// one goroutine per request, which is the legacy behaviour.
+	WorkerPoolSize int
+
+	// WorkerQueueSize is the buffer depth of each worker's own queue.
+	// Only meaningful when WorkerPoolSize > 0.
+	WorkerQueueSize int
+
+	// Backpressure decides what happens when a worker's queue is full.
+	// The zero value is BackpressureReject. Only meaningful when
+	// WorkerPoolSize > 0.
+	Backpressure BackpressurePolicy
+}
+
+func newRouter(opt ...RouterOption) *Router {
+	var o RouterOption
+	if len(opt) != 0 {
+		o = opt[0]
 	}
-	return &Router{
-		reqCtxQueue: make(chan *Context, size),
+	r := &Router{
+		reqCtxQueue: make(chan *Context, o.ReqCtxQueueSize),
 		stopped:     make(chan struct{}),
 	}
+	r.initWorkerPool(o.WorkerPoolSize, o.WorkerQueueSize, o.Backpressure)
+	return r
 }
 
 // Router is a router for incoming message.
@@ -41,6 +63,26 @@ type Router struct {
 	notFoundHandler HandlerFunc
 	reqCtxQueue     chan *Context
 	stopped         chan struct{}
+	stopOnce        sync.Once
+
+	// idJoiner composes RouteGroup prefix chains, see group.go. nil uses defaultRouteIDJoiner.
+	idJoiner RouteIDJoiner
+
+	// onHandlerPanic, if set, is invoked instead of just logging when a
+	// handler panics, see handleAndRespond in workerpool.go.
+	onHandlerPanic func(session *Session, ctx *Context, recovered interface{})
+
+	// wg tracks in-flight handleAndRespond calls, for Shutdown to drain. See shutdown.go.
+	wg sync.WaitGroup
+
+	// worker pool, see workerpool.go. workerPoolSize is 0 when the router
+	// runs in the legacy one-goroutine-per-request mode.
+	workerPoolSize  int
+	workerQueueSize int
+	backpressure    BackpressurePolicy
+	workerQueues    []chan *Context
+	activeWorkers   int32
+	rejections      int64
 }
 
 // HandlerFunc is the function type for handlers.
@@ -49,11 +91,11 @@ type HandlerFunc func(ctx *Context) (*message.Entry, error)
 // MiddlewareFunc is the function type for middlewares.
 // A common pattern is like:
 //
-// 	var md MiddlewareFunc = func(next HandlerFunc) HandlerFunc {
-// 		return func(ctx *Context) (message.Entry, error) {
-// 			return next(ctx)
-// 		}
-// 	}
+//	var md MiddlewareFunc = func(next HandlerFunc) HandlerFunc {
+//		return func(ctx *Context) (message.Entry, error) {
+//			return next(ctx)
+//		}
+//	}
 type MiddlewareFunc func(next HandlerFunc) HandlerFunc
 
 var nilHandler HandlerFunc = func(ctx *Context) (*message.Entry, error) {
@@ -61,7 +103,9 @@ var nilHandler HandlerFunc = func(ctx *Context) (*message.Entry, error) {
 }
 
 func (r *Router) stop() {
-	close(r.stopped)
+	r.stopOnce.Do(func() {
+		close(r.stopped)
+	})
 }
 
 func (r *Router) consumeRequest() {
@@ -69,36 +113,75 @@ func (r *Router) consumeRequest() {
 	for {
 		select {
 		case <-r.stopped:
+			// Drain whatever's already buffered in reqCtxQueue instead of
+			// discarding it: those requests were already read off a
+			// socket and deserve a chance to run, same as Shutdown
+			// drains in-flight handlers.
+			r.drainReqCtxQueue()
 			close(r.reqCtxQueue)
+			// consumeRequest is the only goroutine that ever sends to
+			// workerQueues (via routeReqCtx/dispatch, above), so it's the
+			// only place that can close them without racing a send:
+			// Shutdown used to close them itself, from a goroutine racing
+			// this one, which could close a queue before the drain above
+			// had dispatched everything still buffered in reqCtxQueue.
+			for _, q := range r.workerQueues {
+				close(q)
+			}
 			return
 		case reqCtx, ok := <-r.reqCtxQueue:
 			if !ok {
 				return
 			}
-			select {
-			case <-reqCtx.session.closed:
-				continue
-			default:
-			}
-			if reqCtx.reqMsg == nil {
-				continue
+			r.routeReqCtx(reqCtx)
+		}
+	}
+}
+
+// drainReqCtxQueue processes every *Context already buffered in
+// reqCtxQueue, without blocking for more to arrive. It's called once,
+// from consumeRequest's shutdown branch, so buffered requests aren't
+// silently dropped when the router stops.
+func (r *Router) drainReqCtxQueue() {
+	for {
+		select {
+		case reqCtx, ok := <-r.reqCtxQueue:
+			if !ok {
+				return
 			}
+			r.routeReqCtx(reqCtx)
+		default:
+			return
+		}
+	}
+}
+
+// routeReqCtx dispatches a single *Context to its handler, or to a
+// pending Ask call if it carries a matching correlation ID. It's shared
+// by consumeRequest's normal loop and its shutdown drain.
+func (r *Router) routeReqCtx(reqCtx *Context) {
+	select {
+	case <-reqCtx.session.closed:
+		return
+	default:
+	}
+	if reqCtx.reqMsg == nil {
+		return
+	}
+	if reqCtx.session.resolveCorrelation(reqCtx.reqMsg) {
+		return
+	}
 
-			go func() {
-				respEntry, err := r.handleRequest(reqCtx)
-				if err != nil {
-					Log.Errorf("router handle request err: %s", err)
-					return
-				}
-				if respEntry == nil {
-					return
-				}
-				if err := reqCtx.session.SendResp(respEntry); err != nil {
-					Log.Errorf("router send resp err: %s", err)
-				}
-			}()
+	if r.workerPoolSize > 0 {
+		if err := r.dispatch(reqCtx); err != nil {
+			Log.Errorf("router dispatch request err: %s", err)
 		}
+		return
 	}
+
+	r.wg.Add(1)
+	reqCtx.session.inFlight.Add(1)
+	go r.handleAndRespond(reqCtx)
 }
 
 func (r *Router) handleRequest(ctx *Context) (*message.Entry, error) {
@@ -121,7 +204,8 @@ func (r *Router) handleRequest(ctx *Context) (*message.Entry, error) {
 
 // wrapHandlers wraps handler and middlewares into a right order call stack.
 // Makes something like:
-// 	var wrapped HandlerFunc = m1(m2(m3(handle)))
+//
+//	var wrapped HandlerFunc = m1(m2(m3(handle)))
 func (r *Router) wrapHandlers(handler HandlerFunc, middles []MiddlewareFunc) (wrapped HandlerFunc) {
 	if handler == nil {
 		handler = r.notFoundHandler
@@ -162,18 +246,29 @@ func (r *Router) registerMiddleware(m ...MiddlewareFunc) {
 	}
 }
 
-// printHandlers prints registered route handlers to console.
+// printHandlers prints registered route handlers to console, rendering
+// grouped IDs (the dotted or slash-joined paths RouteGroup produces) as
+// an indented hierarchy instead of a flat list. Rows are sorted by their
+// full ID before indentation, so a group's routes print together; this
+// matters since sync.Map.Range's order is unspecified and a hierarchy
+// printed in random order isn't legible.
 func (r *Router) printHandlers(addr string) {
 	fmt.Printf("\n[EASYTCP ROUTE TABLE]:\n")
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"Message ID", "Route Handler"})
 	table.SetAutoFormatHeaders(false)
+
+	type row struct{ key, id, handlerName string }
+	var rows []row
 	r.handlerMapper.Range(func(key, value interface{}) bool {
-		id := key
 		handlerName := runtime.FuncForPC(reflect.ValueOf(value.(HandlerFunc)).Pointer()).Name()
-		table.Append([]string{fmt.Sprintf("%v", id), handlerName})
+		rows = append(rows, row{key: fmt.Sprintf("%v", key), id: renderRouteID(key), handlerName: handlerName})
 		return true
 	})
+	sort.Slice(rows, func(i, j int) bool { return rows[i].key < rows[j].key })
+	for _, rw := range rows {
+		table.Append([]string{rw.id, rw.handlerName})
+	}
 	table.Render()
 	fmt.Printf("[EASYTCP] Serving at: %s\n\n", addr)
 }
@@ -181,3 +276,12 @@ func (r *Router) printHandlers(addr string) {
 func (r *Router) setNotFoundHandler(handler HandlerFunc) {
 	r.notFoundHandler = handler
 }
+
+// OnHandlerPanic registers fn to be called whenever a handler panics,
+// instead of just logging the recovered value. fn receives the session
+// and request context the panicking handler was running in, plus
+// whatever was passed to panic(). Without this, a handler panic is
+// still recovered, and only logged.
+func (r *Router) OnHandlerPanic(fn func(session *Session, ctx *Context, recovered interface{})) {
+	r.onHandlerPanic = fn
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,6 +20,21 @@ type Session struct {
 	respQueue chan *message.Entry // response queue channel, pushed in SendResp() and popped in WriteLoop()
 	packer    Packer              // to pack and unpack message
 	codec     Codec               // encode/decode message data
+
+	heartbeat    *HeartbeatOption // nil disables the heartbeat subsystem
+	lastRecvNano int64            // unix nano timestamp of the last byte received, updated atomically
+	lastPingNano int64            // unix nano timestamp the last ping was sent, updated atomically
+	onPing       func(*Session)
+	onPong       func(*Session, time.Duration)
+
+	hub *SessionHub // nil if the session isn't bound to any SessionHub
+
+	correlations sync.Map       // correlation ID -> chan *message.Entry, see ask.go
+	inFlight     sync.WaitGroup // in-flight handlers writing to respQueue, see shutdown.go
+	values       sync.Map       // per-connection context store, see store.go
+
+	onCreated func(*Session) // invoked once, at the end of NewSession
+	onClosed  func(*Session) // invoked once, from closeOnce.Do
 }
 
 // SessionOption is the extra options for Session.
@@ -27,6 +43,13 @@ type SessionOption struct {
 	Codec           Codec
 	ReadBufferSize  int
 	WriteBufferSize int
+	Heartbeat       *HeartbeatOption
+	Hub             *SessionHub
+
+	// OnCreated, if set, is invoked once, right after the session is created.
+	OnCreated func(*Session)
+	// OnClosed, if set, is invoked once, right after the session is closed.
+	OnClosed func(*Session)
 }
 
 // NewSession creates a new Session.
@@ -35,15 +58,24 @@ type SessionOption struct {
 // Returns a Session pointer.
 func NewSession(conn net.Conn, opt *SessionOption) *Session {
 	id := uuid.NewString()
-	return &Session{
-		id:        id,
-		conn:      conn,
-		closed:    make(chan struct{}),
-		reqQueue:  make(chan *message.Entry, opt.ReadBufferSize),
-		respQueue: make(chan *message.Entry, opt.WriteBufferSize),
-		packer:    opt.Packer,
-		codec:     opt.Codec,
+	s := &Session{
+		id:           id,
+		conn:         conn,
+		closed:       make(chan struct{}),
+		reqQueue:     make(chan *message.Entry, opt.ReadBufferSize),
+		respQueue:    make(chan *message.Entry, opt.WriteBufferSize),
+		packer:       opt.Packer,
+		codec:        opt.Codec,
+		heartbeat:    opt.Heartbeat,
+		lastRecvNano: time.Now().UnixNano(),
+		hub:          opt.Hub,
+		onCreated:    opt.OnCreated,
+		onClosed:     opt.OnClosed,
 	}
+	if s.onCreated != nil {
+		s.onCreated(s)
+	}
+	return s
 }
 
 // ID implements the Session ID method.
@@ -76,9 +108,15 @@ func (s *Session) SendResp(respMsg *message.Entry) error {
 // Close closes the session by closing all the channels.
 func (s *Session) Close() {
 	s.closeOnce.Do(func() {
+		if s.hub != nil {
+			s.hub.LeaveAll(s)
+		}
 		close(s.closed)
 		close(s.reqQueue)
 		close(s.respQueue)
+		if s.onClosed != nil {
+			s.onClosed(s)
+		}
 	})
 }
 
@@ -101,6 +139,10 @@ func (s *Session) ReadLoop(readTimeout time.Duration) {
 			Log.Tracef("unpack incoming message err: %s", err)
 			break
 		}
+		atomic.StoreInt64(&s.lastRecvNano, time.Now().UnixNano())
+		if s.heartbeat != nil && s.handleHeartbeatMessage(entry) {
+			continue
+		}
 		if !s.safelyPushReqQueue(entry) {
 			break
 		}
@@ -0,0 +1,75 @@
+package easytcp
+
+import "context"
+
+// Shutdown stops the router from accepting new requests — draining
+// whatever's already buffered in reqCtxQueue first, see
+// drainReqCtxQueue — and waits for every in-flight handler (spawned by
+// either dispatch path, see workerpool.go) to return before returning
+// itself. If ctx expires first, Shutdown returns ctx.Err() and any
+// handlers still running are left to finish on their own.
+//
+// Shutdown itself never touches workerQueues: consumeRequest closes them,
+// from its own goroutine, right after its drain finishes. Closing them
+// here instead, from this goroutine, could race r.wg.Wait() returning
+// before the drain even started (r.wg is 0 until something's actually
+// been dispatched) and close a queue out from under a send the drain was
+// still about to make.
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.stop()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown gracefully closes the session: it stops new requests from
+// being read, waits up to ctx's deadline for handlers still writing to
+// respQueue to finish, then closes respQueue so WriteLoop can drain
+// whatever responses are left before the connection is closed. Unlike
+// Close, responses from handlers still in flight aren't dropped unless
+// ctx expires first.
+//
+// s.closed is deliberately left open until the wait below completes:
+// Router's routeReqCtx treats a closed session as "abandon this
+// request", so flipping it early would make the router drop anything
+// still queued for this session instead of letting it drain.
+func (s *Session) Shutdown(ctx context.Context) error {
+	var err error
+	s.closeOnce.Do(func() {
+		if s.hub != nil {
+			s.hub.LeaveAll(s)
+		}
+		close(s.reqQueue) // stop accepting new requests
+
+		done := make(chan struct{})
+		go func() {
+			s.inFlight.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		// Only now is it safe to mark the session closed: anything the
+		// router had queued for it has either been handled above, or,
+		// if ctx expired first, is abandoned deliberately.
+		close(s.closed)
+		close(s.respQueue)
+		if s.onClosed != nil {
+			s.onClosed(s)
+		}
+	})
+	return err
+}
@@ -0,0 +1,155 @@
+package easytcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+// TestRouterShutdownWaitsForInFlightHandlers checks that Shutdown blocks
+// until a handler that's already running returns, rather than racing
+// ahead because wg was never incremented before the handler started.
+func TestRouterShutdownWaitsForInFlightHandlers(t *testing.T) {
+	r := newRouter()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.register("slow", func(ctx *Context) (*message.Entry, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+
+	s := &Session{id: "s1", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	reqCtx := &Context{session: s, reqMsg: &message.Entry{ID: "slow"}}
+
+	// Mirrors what routeReqCtx does: count reqCtx before handing it to
+	// the handler goroutine, not inside handleAndRespond itself.
+	r.wg.Add(1)
+	s.inFlight.Add(1)
+	go r.handleAndRespond(reqCtx)
+
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- r.Shutdown(context.Background()) }()
+
+	select {
+	case <-shutdownDone:
+		t.Fatal("Shutdown returned before the in-flight handler finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			t.Fatalf("Shutdown returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown never returned after the in-flight handler finished")
+	}
+}
+
+// TestSessionShutdownWaitsForInFlightBeforeClosing checks that s.closed
+// isn't flipped until in-flight work has drained, since the router
+// treats a closed session as "abandon anything still queued for it".
+func TestSessionShutdownWaitsForInFlightBeforeClosing(t *testing.T) {
+	s := &Session{
+		closed:    make(chan struct{}),
+		reqQueue:  make(chan *message.Entry, 1),
+		respQueue: make(chan *message.Entry, 1),
+	}
+
+	s.inFlight.Add(1)
+	release := make(chan struct{})
+	go func() {
+		<-release
+		s.inFlight.Done()
+	}()
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- s.Shutdown(context.Background()) }()
+
+	select {
+	case <-s.closed:
+		t.Fatal("session was marked closed before in-flight work drained")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+
+	select {
+	case err := <-shutdownDone:
+		if err != nil {
+			t.Fatalf("Shutdown returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown never returned")
+	}
+
+	select {
+	case <-s.closed:
+	default:
+		t.Fatal("session should be closed once Shutdown has returned")
+	}
+}
+
+// TestShutdownDoesNotRaceConsumeRequestDrain reproduces a request that's
+// already buffered in reqCtxQueue when the router stops, but hasn't been
+// picked up by consumeRequest yet. r.wg is still 0 at that point (nothing's
+// been dispatched), so Shutdown's wg.Wait() can return instantly; it used
+// to then close every workerQueues[i] itself, racing consumeRequest's
+// drain, which could still be about to dispatch this very request into
+// one of those queues — a send on an already-closed channel. Now that
+// only consumeRequest ever closes workerQueues, and only after its own
+// drain, there's nothing left to race.
+func TestShutdownDoesNotRaceConsumeRequestDrain(t *testing.T) {
+	r := newRouter(RouterOption{ReqCtxQueueSize: 1, WorkerPoolSize: 1, WorkerQueueSize: 1})
+	handled := make(chan struct{}, 1)
+	r.register("noop", func(ctx *Context) (*message.Entry, error) {
+		handled <- struct{}{}
+		return nil, nil
+	})
+
+	s := &Session{id: "s1", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 1)}
+	reqCtx := &Context{session: s, reqMsg: &message.Entry{ID: "noop"}}
+
+	// Buffer a request consumeRequest hasn't touched yet, then stop the
+	// router before consumeRequest's goroutine has even started.
+	r.reqCtxQueue <- reqCtx
+	r.stop()
+	go r.consumeRequest()
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	select {
+	case <-handled:
+	default:
+		t.Fatal("request buffered in reqCtxQueue before shutdown was never handled")
+	}
+}
+
+// TestSessionShutdownRespectsContextDeadline checks that Shutdown gives
+// up and reports ctx's error instead of blocking forever on stuck
+// in-flight work.
+func TestSessionShutdownRespectsContextDeadline(t *testing.T) {
+	s := &Session{
+		closed:    make(chan struct{}),
+		reqQueue:  make(chan *message.Entry, 1),
+		respQueue: make(chan *message.Entry, 1),
+	}
+	s.inFlight.Add(1) // deliberately never Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
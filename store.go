@@ -0,0 +1,33 @@
+package easytcp
+
+import "sync"
+
+// Set stores value under key in the session's per-connection context
+// store. Handlers use this to stash state like authenticated user info
+// or a negotiated codec version, instead of a global map keyed by
+// session ID.
+func (s *Session) Set(key, value interface{}) {
+	s.values.Store(key, value)
+}
+
+// Get returns the value stored under key in the session's context
+// store, and whether it was present.
+func (s *Session) Get(key interface{}) (interface{}, bool) {
+	return s.values.Load(key)
+}
+
+// Delete removes key from the session's context store.
+func (s *Session) Delete(key interface{}) {
+	s.values.Delete(key)
+}
+
+// Values returns the session's per-connection context store directly,
+// for callers that want the sync.Map itself (e.g. to Range over it).
+func (s *Session) Values() *sync.Map {
+	return &s.values
+}
+
+// SetSession is a shortcut for ctx.Session().Set(key, value).
+func (c *Context) SetSession(key, value interface{}) {
+	c.session.Set(key, value)
+}
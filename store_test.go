@@ -0,0 +1,47 @@
+package easytcp
+
+import "testing"
+
+func TestSessionStoreSetGetDelete(t *testing.T) {
+	s := &Session{}
+
+	if _, ok := s.Get("key"); ok {
+		t.Fatal("expected no value before Set")
+	}
+
+	s.Set("key", "value")
+	v, ok := s.Get("key")
+	if !ok || v != "value" {
+		t.Fatalf("expected (%q, true), got (%v, %v)", "value", v, ok)
+	}
+
+	s.Delete("key")
+	if _, ok := s.Get("key"); ok {
+		t.Fatal("expected no value after Delete")
+	}
+}
+
+func TestContextSetSession(t *testing.T) {
+	s := &Session{}
+	ctx := &Context{session: s}
+	ctx.SetSession("key", 42)
+	v, ok := s.Get("key")
+	if !ok || v != 42 {
+		t.Fatalf("expected (42, true), got (%v, %v)", v, ok)
+	}
+}
+
+func TestSessionValuesReturnsUnderlyingStore(t *testing.T) {
+	s := &Session{}
+	s.Set("a", 1)
+	found := false
+	s.Values().Range(func(key, value interface{}) bool {
+		if key == "a" && value == 1 {
+			found = true
+		}
+		return true
+	})
+	if !found {
+		t.Fatal("Values() should expose entries set via Set")
+	}
+}
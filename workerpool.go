@@ -0,0 +1,179 @@
+package easytcp
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync/atomic"
+)
+
+// BackpressurePolicy defines what a Router's worker pool does when the
+// queue of the worker a request is routed to is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureReject returns ErrWorkerPoolSaturated to the caller
+	// instead of queueing the request. It's the default: consumeRequest
+	// dispatches from a single goroutine, so a policy that can block on
+	// one session's full queue would stall dispatch to every other
+	// session too. Reject can't do that.
+	BackpressureReject BackpressurePolicy = iota
+
+	// BackpressureDropOldest drops the oldest queued *Context for the
+	// target worker to make room for the incoming one.
+	BackpressureDropOldest
+
+	// BackpressureBlock blocks the dispatching goroutine until the target
+	// worker's queue has room. Because consumeRequest dispatches from a single
+	// goroutine, blocking on one session's full queue stalls dispatch to
+	// every other session as well — only opt into this if you can
+	// tolerate that kind of stall (e.g. a single-session-per-process
+	// deployment).
+	BackpressureBlock
+)
+
+// ErrWorkerPoolSaturated is returned by Router.dispatch when the target
+// worker's queue is full and Backpressure is set to BackpressureReject.
+var ErrWorkerPoolSaturated = fmt.Errorf("easytcp: router worker pool is saturated")
+
+// RouterMetrics is a snapshot of the router's worker pool state.
+type RouterMetrics struct {
+	ActiveWorkers int32
+	QueueDepth    int
+	Rejections    int64
+}
+
+// initWorkerPool sets up the per-worker queues when WorkerPoolSize is
+// positive. It's called once from newRouter.
+func (r *Router) initWorkerPool(workerPoolSize, workerQueueSize int, backpressure BackpressurePolicy) {
+	r.backpressure = backpressure
+	if workerPoolSize <= 0 {
+		return
+	}
+	r.workerPoolSize = workerPoolSize
+	r.workerQueueSize = workerQueueSize
+	r.workerQueues = make([]chan *Context, workerPoolSize)
+	for i := range r.workerQueues {
+		r.workerQueues[i] = make(chan *Context, workerQueueSize)
+		go r.worker(r.workerQueues[i])
+	}
+}
+
+// worker reads *Context off its own queue and handles requests one at a
+// time, so messages from the same session (see workerIndex) are always
+// processed in order.
+func (r *Router) worker(queue chan *Context) {
+	for reqCtx := range queue {
+		atomic.AddInt32(&r.activeWorkers, 1)
+		r.handleAndRespond(reqCtx)
+		atomic.AddInt32(&r.activeWorkers, -1)
+	}
+}
+
+// workerIndex picks a worker deterministically by session ID, so a single
+// session's messages always land on the same worker and stay ordered.
+func (r *Router) workerIndex(sessionID string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(sessionID))
+	return int(h.Sum32()) % r.workerPoolSize
+}
+
+// dispatch sends reqCtx to the appropriate worker queue, applying the
+// configured BackpressurePolicy if that queue is full. In worker-pool
+// mode it's the single place reqCtx is counted toward r.wg / the
+// session's inFlight group, so
+// Shutdown can only observe it as done once a worker has actually run it
+// through handleAndRespond (or it was never queued at all).
+func (r *Router) dispatch(reqCtx *Context) error {
+	queue := r.workerQueues[r.workerIndex(reqCtx.session.id)]
+	r.trackQueued(reqCtx)
+	switch r.backpressure {
+	case BackpressureReject:
+		select {
+		case queue <- reqCtx:
+			return nil
+		default:
+			r.untrackQueued(reqCtx)
+			atomic.AddInt64(&r.rejections, 1)
+			return ErrWorkerPoolSaturated
+		}
+	case BackpressureDropOldest:
+		for {
+			select {
+			case queue <- reqCtx:
+				return nil
+			default:
+				select {
+				case dropped := <-queue:
+					r.untrackQueued(dropped)
+				default:
+				}
+			}
+		}
+	default: // BackpressureBlock
+		queue <- reqCtx
+		return nil
+	}
+}
+
+// trackQueued counts reqCtx as in-flight, before it's handed to a worker
+// queue or goroutine. Must be paired with exactly one of untrackQueued
+// (if it never reaches a worker) or handleAndRespond's Done calls (once
+// it does).
+func (r *Router) trackQueued(reqCtx *Context) {
+	r.wg.Add(1)
+	reqCtx.session.inFlight.Add(1)
+}
+
+// untrackQueued undoes trackQueued for a reqCtx that was rejected, or
+// evicted by BackpressureDropOldest, before a worker ever saw it.
+func (r *Router) untrackQueued(reqCtx *Context) {
+	r.wg.Done()
+	reqCtx.session.inFlight.Done()
+}
+
+// handleAndRespond runs the handler chain for reqCtx and sends the
+// response back through the owning session, logging any failure. It's
+// shared by both the pooled and the one-goroutine-per-request dispatch
+// paths. The caller must have already counted reqCtx toward r.wg and
+// reqCtx.session.inFlight (routeReqCtx and dispatch's trackQueued do
+// this before handing reqCtx off), since that has to happen in the
+// dispatching goroutine, not in here, for Shutdown's Wait to be race-free.
+func (r *Router) handleAndRespond(reqCtx *Context) {
+	defer r.wg.Done()
+	defer reqCtx.session.inFlight.Done()
+	defer func() {
+		if rec := recover(); rec != nil {
+			if r.onHandlerPanic != nil {
+				r.onHandlerPanic(reqCtx.session, reqCtx, rec)
+				return
+			}
+			Log.Errorf("router handler panic: %+v", rec)
+		}
+	}()
+
+	respEntry, err := r.handleRequest(reqCtx)
+	if err != nil {
+		Log.Errorf("router handle request err: %s", err)
+		return
+	}
+	if respEntry == nil {
+		return
+	}
+	if err := reqCtx.session.SendResp(respEntry); err != nil {
+		Log.Errorf("router send resp err: %s", err)
+	}
+}
+
+// Metrics returns a snapshot of the worker pool's current state. When the
+// router isn't running in worker-pool mode, QueueDepth is always 0.
+func (r *Router) Metrics() RouterMetrics {
+	depth := 0
+	for _, q := range r.workerQueues {
+		depth += len(q)
+	}
+	return RouterMetrics{
+		ActiveWorkers: atomic.LoadInt32(&r.activeWorkers),
+		QueueDepth:    depth,
+		Rejections:    atomic.LoadInt64(&r.rejections),
+	}
+}
@@ -0,0 +1,120 @@
+package easytcp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DarthPestilane/easytcp/message"
+)
+
+// TestDispatchDefaultIsNonBlocking guards against the head-of-line
+// convoy bug: with the default BackpressurePolicy, a saturated worker
+// queue must reject immediately, never block the caller.
+func TestDispatchDefaultIsNonBlocking(t *testing.T) {
+	r := &Router{
+		workerPoolSize:  1,
+		workerQueueSize: 1,
+		workerQueues:    []chan *Context{make(chan *Context, 1)},
+	}
+	s1 := &Session{id: "s1"}
+	s2 := &Session{id: "s2"}
+
+	if err := r.dispatch(&Context{session: s1}); err != nil {
+		t.Fatalf("first dispatch should succeed, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- r.dispatch(&Context{session: s2}) }()
+
+	select {
+	case err := <-done:
+		if err != ErrWorkerPoolSaturated {
+			t.Fatalf("expected ErrWorkerPoolSaturated, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dispatch blocked instead of rejecting under the default backpressure policy")
+	}
+}
+
+// TestDispatchBlockPolicyBlocksUntilRoom checks that BackpressureBlock,
+// now opt-in, still behaves as documented: it blocks until room frees up.
+func TestDispatchBlockPolicyBlocksUntilRoom(t *testing.T) {
+	r := &Router{
+		workerPoolSize:  1,
+		workerQueueSize: 1,
+		workerQueues:    []chan *Context{make(chan *Context, 1)},
+		backpressure:    BackpressureBlock,
+	}
+	s := &Session{id: "s1"}
+
+	if err := r.dispatch(&Context{session: s}); err != nil {
+		t.Fatalf("first dispatch: %v", err)
+	}
+
+	blocked := make(chan struct{})
+	go func() {
+		_ = r.dispatch(&Context{session: s})
+		close(blocked)
+	}()
+
+	select {
+	case <-blocked:
+		t.Fatal("BackpressureBlock dispatch returned before the queue had room")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-r.workerQueues[0] // make room
+
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("BackpressureBlock dispatch never unblocked after room was made")
+	}
+}
+
+// TestWorkerPoolPreservesPerSessionOrder checks that, despite being
+// spread across a pool of workers, messages from a single session are
+// still handled in the order they were dispatched.
+func TestWorkerPoolPreservesPerSessionOrder(t *testing.T) {
+	r := newRouter(RouterOption{WorkerPoolSize: 4, WorkerQueueSize: 32})
+	defer r.stop()
+
+	var mu sync.Mutex
+	var order []int
+	r.register("echo", func(ctx *Context) (*message.Entry, error) {
+		mu.Lock()
+		order = append(order, int(ctx.reqMsg.Data[0]))
+		mu.Unlock()
+		return nil, nil
+	})
+
+	s := &Session{id: "session-a", closed: make(chan struct{}), respQueue: make(chan *message.Entry, 32)}
+	const n = 20
+	for i := 0; i < n; i++ {
+		reqCtx := &Context{session: s, reqMsg: &message.Entry{ID: "echo", Data: []byte{byte(i)}}}
+		if err := r.dispatch(reqCtx); err != nil {
+			t.Fatalf("dispatch %d: %v", i, err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := len(order)
+		mu.Unlock()
+		if got == n {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("only processed %d/%d messages before timing out", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i, v := range order {
+		if v != i {
+			t.Fatalf("messages for one session were reordered: got %v", order)
+		}
+	}
+}